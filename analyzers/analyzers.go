@@ -0,0 +1,30 @@
+// Package analyzers dispatches to the per-ecosystem analyzer implementations
+// used by `fossa analyze`.
+package analyzers
+
+import (
+	"errors"
+
+	"github.com/fossas/fossa-cli/analyzers/nodejs"
+	"github.com/fossas/fossa-cli/module"
+	"github.com/fossas/fossa-cli/pkg"
+)
+
+// ErrUnknownModuleType is returned when no analyzer is registered for a
+// module's type.
+var ErrUnknownModuleType = errors.New("unknown module type")
+
+// Analyzer determines the dependencies of a Module.
+type Analyzer interface {
+	Analyze() (pkg.Output, error)
+}
+
+// New constructs the Analyzer for m's type.
+func New(m module.Module, strict bool) (Analyzer, error) {
+	switch m.Type {
+	case pkg.NodeJS:
+		return nodejs.New(m, strict)
+	default:
+		return nil, ErrUnknownModuleType
+	}
+}
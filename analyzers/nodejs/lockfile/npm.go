@@ -0,0 +1,44 @@
+// Package lockfile parses the various npm/yarn/pnpm lockfile formats, each
+// into its own type, so that analyzers/nodejs can fold them into a pkg.Deps
+// graph without needing `npm ls` or an installed node_modules tree.
+package lockfile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// NpmPackage is a single entry in the legacy (lockfileVersion 1) nested
+// `dependencies` tree of a package-lock.json/npm-shrinkwrap.json.
+type NpmPackage struct {
+	Version      string                `json:"version"`
+	Resolved     string                `json:"resolved"`
+	Integrity    string                `json:"integrity"`
+	Dev          bool                  `json:"dev"`
+	Optional     bool                  `json:"optional"`
+	Requires     map[string]string     `json:"requires"`
+	Dependencies map[string]NpmPackage `json:"dependencies"`
+}
+
+// NpmLockfileV1 is a legacy (lockfileVersion 1) package-lock.json or
+// npm-shrinkwrap.json.
+type NpmLockfileV1 struct {
+	Name            string                `json:"name"`
+	Version         string                `json:"version"`
+	LockfileVersion int                   `json:"lockfileVersion"`
+	Dependencies    map[string]NpmPackage `json:"dependencies"`
+}
+
+// ReadNpmLockfileV1 parses a legacy package-lock.json/npm-shrinkwrap.json.
+func ReadNpmLockfileV1(path string) (NpmLockfileV1, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return NpmLockfileV1{}, err
+	}
+
+	var lockfile NpmLockfileV1
+	if err := json.Unmarshal(contents, &lockfile); err != nil {
+		return NpmLockfileV1{}, err
+	}
+	return lockfile, nil
+}
@@ -0,0 +1,69 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// NpmPackageV2 is a single entry in the flat `packages` map of a
+// lockfileVersion 2 or 3 package-lock.json/npm-shrinkwrap.json, keyed by its
+// install path (e.g. "", "node_modules/chai",
+// "node_modules/chai/node_modules/type-detect").
+type NpmPackageV2 struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Integrity            string            `json:"integrity"`
+	Dev                  bool              `json:"dev"`
+	Optional             bool              `json:"optional"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// NpmLockfileV2 is a lockfileVersion 2 or 3 package-lock.json/
+// npm-shrinkwrap.json. Both versions share the `packages` representation;
+// v2 additionally carries the legacy nested `dependencies` tree for
+// backwards compatibility with npm 6, which we ignore in favor of
+// `packages`.
+type NpmLockfileV2 struct {
+	Name            string                  `json:"name"`
+	Version         string                  `json:"version"`
+	LockfileVersion int                     `json:"lockfileVersion"`
+	Packages        map[string]NpmPackageV2 `json:"packages"`
+}
+
+// ReadNpmLockfileV2 parses a lockfileVersion 2 or 3 package-lock.json/
+// npm-shrinkwrap.json.
+func ReadNpmLockfileV2(path string) (NpmLockfileV2, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return NpmLockfileV2{}, err
+	}
+
+	var lockfile NpmLockfileV2
+	if err := json.Unmarshal(contents, &lockfile); err != nil {
+		return NpmLockfileV2{}, err
+	}
+	return lockfile, nil
+}
+
+// NpmLockfileVersion peeks at a package-lock.json/npm-shrinkwrap.json just
+// far enough to learn which format the rest of the file is in, so callers
+// can pick between ReadNpmLockfileV1 and ReadNpmLockfileV2.
+func NpmLockfileVersion(path string) (int, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var header struct {
+		LockfileVersion int `json:"lockfileVersion"`
+	}
+	if err := json.Unmarshal(contents, &header); err != nil {
+		return 0, err
+	}
+	return header.LockfileVersion, nil
+}
@@ -0,0 +1,115 @@
+package lockfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PnpmImporter is a single workspace root's view of the lockfile: what it
+// asked for (Specifiers) and what got resolved (Dependencies).
+type PnpmImporter struct {
+	Specifiers           map[string]string `yaml:"specifiers"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	DevDependencies      map[string]string `yaml:"devDependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+}
+
+// PnpmPackage is a single resolved package block under pnpm-lock.yaml's
+// top-level `packages` map, keyed by a specifier like "/chai/4.1.2" or
+// "/foo/1.0.0_bar@2.0.0" (the `_...` suffix disambiguates peer-dependency
+// variants of the same name+version).
+type PnpmPackage struct {
+	Resolution struct {
+		Integrity string `yaml:"integrity"`
+		Tarball   string `yaml:"tarball"`
+	} `yaml:"resolution"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+	PeerDependencies     map[string]string `yaml:"peerDependencies"`
+	Dev                  bool              `yaml:"dev"`
+	Optional             bool              `yaml:"optional"`
+}
+
+// PnpmLockfile is a parsed pnpm-lock.yaml. Single-package repos resolve
+// their dependencies directly off the top-level Dependencies/
+// DevDependencies/OptionalDependencies fields; workspaces resolve them
+// per-member via Importers instead.
+type PnpmLockfile struct {
+	LockfileVersion      string                  `yaml:"lockfileVersion"`
+	Importers            map[string]PnpmImporter `yaml:"importers"`
+	Dependencies         map[string]string       `yaml:"dependencies"`
+	DevDependencies      map[string]string       `yaml:"devDependencies"`
+	OptionalDependencies map[string]string       `yaml:"optionalDependencies"`
+	Packages             map[string]PnpmPackage  `yaml:"packages"`
+}
+
+// ReadPnpmLockfile parses a pnpm-lock.yaml file.
+func ReadPnpmLockfile(path string) (PnpmLockfile, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PnpmLockfile{}, err
+	}
+
+	// pnpm emits `lockfileVersion` as a bare number in some versions and a
+	// quoted string in others; decode permissively into an interface{}
+	// first so both shapes unmarshal cleanly.
+	var raw struct {
+		LockfileVersion      interface{}             `yaml:"lockfileVersion"`
+		Importers            map[string]PnpmImporter `yaml:"importers"`
+		Dependencies         map[string]string       `yaml:"dependencies"`
+		DevDependencies      map[string]string       `yaml:"devDependencies"`
+		OptionalDependencies map[string]string       `yaml:"optionalDependencies"`
+		Packages             map[string]PnpmPackage  `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(contents, &raw); err != nil {
+		return PnpmLockfile{}, err
+	}
+
+	return PnpmLockfile{
+		LockfileVersion:      toString(raw.LockfileVersion),
+		Importers:            raw.Importers,
+		Dependencies:         raw.Dependencies,
+		DevDependencies:      raw.DevDependencies,
+		OptionalDependencies: raw.OptionalDependencies,
+		Packages:             raw.Packages,
+	}, nil
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// PnpmDependencyKey builds the `packages` map key for a dependency, given
+// its name and the specifier pnpm recorded for it (a bare version like
+// "4.1.2", or a peer-qualified one like "1.0.0_bar@2.0.0").
+func PnpmDependencyKey(name, specifier string) string {
+	return "/" + name + "/" + specifier
+}
+
+// ParsePnpmPackageKey splits a `packages` map key into the package's name
+// and version, discarding any peer-dependency disambiguation suffix so that
+// "/foo/1.0.0_bar@2.0.0" and "/foo/1.0.0_baz@1.0.0" both report version
+// "1.0.0".
+func ParsePnpmPackageKey(key string) (name, version string) {
+	trimmed := strings.TrimPrefix(key, "/")
+	idx := strings.LastIndex(trimmed, "/")
+	if idx < 0 {
+		return trimmed, ""
+	}
+	name = trimmed[:idx]
+	version = trimmed[idx+1:]
+	if i := strings.Index(version, "_"); i >= 0 {
+		version = version[:i]
+	}
+	return name, version
+}
@@ -0,0 +1,97 @@
+package lockfile
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// YarnPackage is a single resolved block in a classic (v1) yarn.lock.
+type YarnPackage struct {
+	Version      string
+	Resolved     string
+	Dependencies map[string]string
+}
+
+// YarnLockfileV1 maps every specifier that appears in a yarn.lock (e.g.
+// "chai@^4.1.2") to the resolved package it was satisfied by. Multiple
+// specifiers commonly point at the same YarnPackage.
+type YarnLockfileV1 map[string]YarnPackage
+
+// ReadYarnLockfileV1 parses a classic yarn.lock file.
+//
+// Classic yarn.lock is a hand-rolled, YAML-like format rather than real
+// YAML, so we parse it with a small line-oriented scanner instead of a YAML
+// library.
+func ReadYarnLockfileV1(path string) (YarnLockfileV1, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lockfile := make(YarnLockfileV1)
+	lines := strings.Split(string(contents), "\n")
+
+	var keys []string
+	var pkg YarnPackage
+	inDependencies := false
+
+	flush := func() {
+		for _, key := range keys {
+			lockfile[key] = pkg
+		}
+		keys = nil
+		pkg = YarnPackage{}
+		inDependencies = false
+	}
+
+	for _, raw := range lines {
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case indent == 0:
+			// A new specifier block, e.g. `"chai@^4.1.2", chai@4.1.2:`
+			flush()
+			header := strings.TrimSuffix(trimmed, ":")
+			for _, spec := range strings.Split(header, ", ") {
+				keys = append(keys, strings.Trim(spec, `"`))
+			}
+		case indent == 2:
+			inDependencies = false
+			switch {
+			case strings.HasPrefix(trimmed, "version"):
+				pkg.Version = unquote(strings.TrimSpace(strings.TrimPrefix(trimmed, "version")))
+			case strings.HasPrefix(trimmed, "resolved"):
+				pkg.Resolved = unquote(strings.TrimSpace(strings.TrimPrefix(trimmed, "resolved")))
+			case trimmed == "dependencies:":
+				inDependencies = true
+				if pkg.Dependencies == nil {
+					pkg.Dependencies = make(map[string]string)
+				}
+			}
+		default:
+			if inDependencies {
+				fields := strings.SplitN(trimmed, " ", 2)
+				if len(fields) == 2 {
+					if pkg.Dependencies == nil {
+						pkg.Dependencies = make(map[string]string)
+					}
+					pkg.Dependencies[unquote(fields[0])] = unquote(fields[1])
+				}
+			}
+		}
+	}
+	flush()
+
+	return lockfile, nil
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"`)
+}
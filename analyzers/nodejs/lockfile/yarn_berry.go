@@ -0,0 +1,125 @@
+package lockfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// YarnBerryPackage is a single resolved entry in a Yarn Berry (v2+)
+// yarn.lock, keyed by one or more comma-separated specifiers that share a
+// resolution (e.g. "chai@npm:^4.1.2, chai@npm:4.1.2").
+type YarnBerryPackage struct {
+	Version      string
+	Resolution   string
+	Dependencies map[string]string
+}
+
+// YarnLockfileBerry maps every specifier that appears in a Yarn Berry
+// yarn.lock to the resolved package it was satisfied by.
+type YarnLockfileBerry map[string]YarnBerryPackage
+
+// IsYarnBerryLockfile reports whether the yarn.lock at path opens with a
+// `__metadata` header, which distinguishes the Berry (v2+) format from
+// classic (v1) yarn.lock.
+func IsYarnBerryLockfile(path string) (bool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		return trimmed == "__metadata:", nil
+	}
+	return false, nil
+}
+
+// ReadYarnLockfileBerry parses a Yarn Berry (v2+) yarn.lock. Unlike the
+// classic v1 format, Berry lockfiles are real YAML, so we parse them with
+// yaml.v2 rather than a hand-rolled scanner.
+func ReadYarnLockfileBerry(path string) (YarnLockfileBerry, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]yaml.MapSlice
+	if err := yaml.Unmarshal(contents, &raw); err != nil {
+		return nil, err
+	}
+
+	lockfile := make(YarnLockfileBerry)
+	for header, fields := range raw {
+		if header == "__metadata" {
+			continue
+		}
+
+		var entry YarnBerryPackage
+		for _, field := range fields {
+			key, _ := field.Key.(string)
+			switch key {
+			case "version":
+				entry.Version = fmt.Sprint(field.Value)
+			case "resolution":
+				entry.Resolution = fmt.Sprint(field.Value)
+			case "dependencies":
+				entry.Dependencies = yarnBerryStringMap(field.Value)
+			}
+		}
+
+		for _, spec := range strings.Split(header, ", ") {
+			lockfile[strings.Trim(spec, `"`)] = entry
+		}
+	}
+
+	return lockfile, nil
+}
+
+// YarnBerryProtocol extracts the resolver protocol ("npm", "workspace",
+// "patch", ...) from a Yarn Berry `resolution` field, e.g. "npm" from
+// "chai@npm:4.1.2" or "workspace" from "foo@workspace:packages/foo".
+func YarnBerryProtocol(resolution string) string {
+	_, rest := splitYarnBerrySpecifier(resolution)
+	if idx := strings.Index(rest, ":"); idx >= 0 {
+		return rest[:idx]
+	}
+	return rest
+}
+
+// splitYarnBerrySpecifier splits a Yarn Berry specifier ("chai@npm:4.1.2",
+// "@babel/core@npm:7.0.0") into its package name and the protocol:reference
+// remainder, accounting for scoped package names already containing an "@".
+func splitYarnBerrySpecifier(spec string) (name, rest string) {
+	search := spec
+	offset := 0
+	if strings.HasPrefix(spec, "@") {
+		offset = 1
+		search = spec[1:]
+	}
+
+	idx := strings.Index(search, "@")
+	if idx < 0 {
+		return spec, ""
+	}
+	return spec[:offset+idx], spec[offset+idx+1:]
+}
+
+func yarnBerryStringMap(value interface{}) map[string]string {
+	slice, ok := value.(yaml.MapSlice)
+	if !ok {
+		return nil
+	}
+
+	m := make(map[string]string, len(slice))
+	for _, item := range slice {
+		key, _ := item.Key.(string)
+		m[key] = fmt.Sprint(item.Value)
+	}
+	return m
+}
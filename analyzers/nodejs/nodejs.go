@@ -0,0 +1,626 @@
+// Package nodejs analyzes NodeJS modules (package.json) by preferring a
+// real `npm ls` run, and falling back to reading an installed
+// node_modules/ tree or a lockfile when `npm` isn't usable.
+package nodejs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fossas/fossa-cli/analyzers/nodejs/lockfile"
+	"github.com/fossas/fossa-cli/module"
+	"github.com/fossas/fossa-cli/pkg"
+)
+
+// Analyzer analyzes NodeJS modules.
+type Analyzer struct {
+	Module module.Module
+	NPM    NPM
+}
+
+// New constructs an Analyzer for m.
+func New(m module.Module, strict bool) (*Analyzer, error) {
+	return &Analyzer{
+		Module: m,
+		NPM:    SystemNPM{},
+	}, nil
+}
+
+// Analyze runs `npm ls --json` and, if that fails, falls back to reading an
+// installed node_modules/ tree or a lockfile directly. With
+// Options["strategy"] set to "workspaces", it instead expands the module
+// into its workspace sub-projects (see analyzeWorkspaces). With
+// Options["strategy"] set to "lockfile", it computes the transitive closure
+// directly from the lockfile, skipping `npm ls` and node_modules/ entirely
+// (see analyzeLockfile).
+func (a *Analyzer) Analyze() (pkg.Output, error) {
+	switch a.Module.Options["strategy"] {
+	case "workspaces":
+		return a.analyzeWorkspaces()
+	case "lockfile":
+		return a.analyzeLockfile()
+	}
+
+	output, err := a.NPM.List(a.dir())
+	if err == nil {
+		return fromNpmOutput(output)
+	}
+	return a.fallback(nil)
+}
+
+// dir is the directory containing this module's package.json.
+func (a *Analyzer) dir() string {
+	if a.Module.Dir != "" {
+		return a.Module.Dir
+	}
+	return a.Module.BuildTarget
+}
+
+// fromNpmOutput builds a pkg.Deps from the output of `npm ls --json`.
+func fromNpmOutput(output Output) (pkg.Output, error) {
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var walk func(deps map[string]OutputDep) pkg.Imports
+	walk = func(deps map[string]OutputDep) pkg.Imports {
+		var imports pkg.Imports
+		for _, name := range sortedKeys(deps) {
+			dep := deps[name]
+			if dep.PeerMissing {
+				continue
+			}
+
+			id := pkg.ID{Type: pkg.NodeJS, Name: name, Revision: dep.Version, Location: dep.Resolved}
+			imports = append(imports, pkg.Import{Target: name, Resolved: id})
+
+			if visited[id] {
+				// Duplicate entry: npm only lists a package's own dependencies
+				// on its first occurrence in the tree, so keep whichever
+				// entry we saw first instead of overwriting it with a
+				// deduplicated stub. See #257.
+				continue
+			}
+			visited[id] = true
+			transitive[id] = pkg.Package{ID: id, Imports: walk(dep.Dependencies)}
+		}
+		return imports
+	}
+
+	direct := walk(output.Dependencies)
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// fallback analyzes a module without shelling out to `npm`, preferring an
+// installed node_modules/ tree (which carries the most precise resolution
+// information) and otherwise reading whichever lockfile is present.
+//
+// extraSeeds merges in additional top-level dependency names to resolve
+// alongside the module's own package.json (e.g. a workspace's members'
+// dependencies, which a monorepo root's own package.json typically doesn't
+// redeclare). The node_modules/ and npm lockfile paths use only the map's
+// names; the yarn paths look each name up by its value as a version range;
+// the pnpm path reads each value as a resolved pnpm specifier, so a plain
+// range there matches no `packages` entry and is dropped. Pass nil when
+// there's nothing extra to seed.
+func (a *Analyzer) fallback(extraSeeds map[string]string) (pkg.Output, error) {
+	dir := a.dir()
+
+	if entries, err := ioutil.ReadDir(filepath.Join(dir, "node_modules")); err == nil && len(entries) > 0 {
+		return fallbackFromNodeModules(dir, extraSeeds)
+	}
+	if path := filepath.Join(dir, "npm-shrinkwrap.json"); exists(path) {
+		return fallbackFromNpmLockfile(path, extraSeeds)
+	}
+	if path := filepath.Join(dir, "package-lock.json"); exists(path) {
+		return fallbackFromNpmLockfile(path, extraSeeds)
+	}
+	if path := filepath.Join(dir, "pnpm-lock.yaml"); exists(path) {
+		return fallbackFromPnpmLockfile(path, extraSeeds)
+	}
+	if path := filepath.Join(dir, "yarn.lock"); exists(path) {
+		return fallbackFromYarnLockfile(path, extraSeeds)
+	}
+
+	return pkg.Output{}, errors.New("could not find npm, a lockfile, or an installed node_modules to analyze")
+}
+
+// analyzeLockfile computes the transitive dependency closure purely from
+// whichever lockfile is present, never consulting an installed
+// node_modules/ tree or shelling out to `npm ls`. This trades the precision
+// of an actual install (which may resolve platform-specific optional
+// dependencies or hoisting differently) for speed on cold CI machines where
+// neither npm nor a node_modules/ tree is available yet.
+func (a *Analyzer) analyzeLockfile() (pkg.Output, error) {
+	dir := a.dir()
+
+	if path := filepath.Join(dir, "npm-shrinkwrap.json"); exists(path) {
+		return fallbackFromNpmLockfile(path, nil)
+	}
+	if path := filepath.Join(dir, "package-lock.json"); exists(path) {
+		return fallbackFromNpmLockfile(path, nil)
+	}
+	if path := filepath.Join(dir, "pnpm-lock.yaml"); exists(path) {
+		return fallbackFromPnpmLockfile(path, nil)
+	}
+	if path := filepath.Join(dir, "yarn.lock"); exists(path) {
+		return fallbackFromYarnLockfile(path, nil)
+	}
+
+	return pkg.Output{}, errors.New("could not find a lockfile to analyze")
+}
+
+// fallbackFromNpmLockfile dispatches to the parser matching the lockfile's
+// declared `lockfileVersion`: the legacy nested format (version 1, the
+// default when the field is absent) or the flat `packages` format (version
+// 2 or 3) introduced with npm 7.
+func fallbackFromNpmLockfile(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	version, err := lockfile.NpmLockfileVersion(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	if version >= 2 {
+		return fallbackFromNpmLockfileV2(path, extraSeeds)
+	}
+	return fallbackFromNpmLockfileV1(path, extraSeeds)
+}
+
+// fallbackFromNodeModules resolves a module's dependency graph by walking an
+// installed node_modules/ tree, honoring npm's nested node_modules/ scoping
+// and falling back to ancestor scopes when a dependency is hoisted.
+func fallbackFromNodeModules(dir string, extraSeeds map[string]string) (pkg.Output, error) {
+	manifest, err := readPackageJSON(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var resolve func(scopes []string, name string) (pkg.ID, bool)
+	resolve = func(scopes []string, name string) (pkg.ID, bool) {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			pkgDir := filepath.Join(scopes[i], name)
+			manifest, err := readPackageJSON(filepath.Join(pkgDir, "package.json"))
+			if err != nil {
+				continue
+			}
+
+			id := pkg.ID{Type: pkg.NodeJS, Name: manifest.Name, Revision: manifest.Version}
+			if visited[id] {
+				return id, true
+			}
+			visited[id] = true
+
+			childScopes := append(append([]string{}, scopes...), filepath.Join(pkgDir, "node_modules"))
+			var imports pkg.Imports
+			for _, depName := range sortedStringKeys(manifest.Dependencies) {
+				if childID, ok := resolve(childScopes, depName); ok {
+					imports = append(imports, pkg.Import{Target: depName, Resolved: childID})
+				}
+			}
+			transitive[id] = pkg.Package{ID: id, Imports: imports}
+			return id, true
+		}
+		return pkg.ID{}, false
+	}
+
+	root := []string{filepath.Join(dir, "node_modules")}
+	var direct pkg.Imports
+	for _, name := range sortedStringKeys(mergeDeps(manifest.Dependencies, manifest.DevDependencies, extraSeeds)) {
+		if id, ok := resolve(root, name); ok {
+			direct = append(direct, pkg.Import{Target: name, Resolved: id})
+		}
+	}
+
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// fallbackFromNpmLockfileV1 resolves a module's dependency graph from a
+// legacy (lockfileVersion 1) package-lock.json or npm-shrinkwrap.json. Its
+// nested `dependencies` tree is populated only for packages that needed a
+// locally-pinned version to resolve a conflict; the rest are hoisted to an
+// ancestor's (often the root's) `dependencies` map, so a package's logical
+// dependency list comes from its `requires` field, resolved by walking up
+// from its own nesting scope to the root the same way node_modules/ itself
+// is resolved at install time.
+func fallbackFromNpmLockfileV1(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	lock, err := lockfile.ReadNpmLockfileV1(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	manifest, err := readPackageJSON(filepath.Join(filepath.Dir(path), "package.json"))
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var resolve func(scopes []map[string]lockfile.NpmPackage, name string) (pkg.ID, bool)
+	resolve = func(scopes []map[string]lockfile.NpmPackage, name string) (pkg.ID, bool) {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			entry, ok := scopes[i][name]
+			if !ok {
+				continue
+			}
+
+			id := pkg.ID{Type: pkg.NodeJS, Name: name, Revision: entry.Version}
+			if visited[id] {
+				return id, true
+			}
+			visited[id] = true
+
+			childScopes := append(append([]map[string]lockfile.NpmPackage{}, scopes[:i+1]...), entry.Dependencies)
+			var imports pkg.Imports
+			for _, depName := range sortedStringKeys(entry.Requires) {
+				if childID, ok := resolve(childScopes, depName); ok {
+					imports = append(imports, pkg.Import{Target: depName, Resolved: childID})
+				}
+			}
+			transitive[id] = pkg.Package{ID: id, Imports: imports}
+			return id, true
+		}
+		return pkg.ID{}, false
+	}
+
+	root := []map[string]lockfile.NpmPackage{lock.Dependencies}
+	var direct pkg.Imports
+	for _, name := range sortedStringKeys(mergeDeps(manifest.Dependencies, manifest.DevDependencies, extraSeeds)) {
+		if id, ok := resolve(root, name); ok {
+			direct = append(direct, pkg.Import{Target: name, Resolved: id})
+		}
+	}
+
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// fallbackFromNpmLockfileV2 resolves a module's dependency graph from a
+// lockfileVersion 2 or 3 package-lock.json/npm-shrinkwrap.json, whose
+// `packages` map is flat and keyed by install path. A dependency's install
+// path is found by walking up from its parent's install path the same way
+// npm itself resolves `require()`: prefer the nearest nested
+// node_modules/<name>, falling back to ancestor scopes until the root.
+func fallbackFromNpmLockfileV2(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	lock, err := lockfile.ReadNpmLockfileV2(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	root, ok := lock.Packages[""]
+	if !ok {
+		return pkg.Output{}, errors.New("package-lock.json is missing its root \"\" package entry")
+	}
+
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var walk func(installPath string, deps map[string]string) pkg.Imports
+	walk = func(installPath string, deps map[string]string) pkg.Imports {
+		var imports pkg.Imports
+		for _, name := range sortedStringKeys(deps) {
+			childPath, entry, ok := resolveNpmV2Package(lock.Packages, installPath, name)
+			if !ok {
+				// Not installed: an optional dependency, or a peer
+				// dependency that went unmet. Either way npm already
+				// decided not to fail the install over it (a required peer
+				// going unmet fails `npm install` itself), so it's always
+				// safe to drop here.
+				continue
+			}
+			if entry.Link {
+				// Workspace symlinks are handled by the workspaces analysis
+				// strategy; skip them here to avoid resolving a package
+				// against itself.
+				continue
+			}
+
+			id := pkg.ID{Type: pkg.NodeJS, Name: name, Revision: entry.Version}
+			imports = append(imports, pkg.Import{Target: name, Resolved: id})
+
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			transitive[id] = pkg.Package{ID: id, Imports: walk(childPath, mergeDeps(entry.Dependencies, entry.OptionalDependencies, entry.PeerDependencies))}
+		}
+		return imports
+	}
+
+	direct := walk("", mergeDeps(root.Dependencies, root.DevDependencies, root.PeerDependencies, extraSeeds))
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// resolveNpmV2Package finds the `packages` entry that satisfies `name` as
+// required from installPath, mirroring Node's module resolution: the
+// nearest nested node_modules/<name> wins, falling back to ancestor scopes
+// up to the lockfile root.
+func resolveNpmV2Package(packages map[string]lockfile.NpmPackageV2, installPath, name string) (string, lockfile.NpmPackageV2, bool) {
+	scopes := npmV2Scopes(installPath)
+	for i := len(scopes); i >= 0; i-- {
+		candidate := "node_modules/" + strings.Join(append(append([]string{}, scopes[:i]...), name), "/node_modules/")
+		if entry, ok := packages[candidate]; ok {
+			return candidate, entry, true
+		}
+	}
+	return "", lockfile.NpmPackageV2{}, false
+}
+
+// npmV2Scopes splits a `packages` install path ("",
+// "node_modules/chai/node_modules/type-detect", ...) into its ordered list
+// of nested package names ([], ["chai", "type-detect"], ...).
+func npmV2Scopes(installPath string) []string {
+	if installPath == "" {
+		return nil
+	}
+	scopes := strings.Split(installPath, "/node_modules/")
+	scopes[0] = strings.TrimPrefix(scopes[0], "node_modules/")
+	return scopes
+}
+
+// fallbackFromPnpmLockfile resolves a module's dependency graph from a
+// pnpm-lock.yaml. Workspace roots are resolved via the matching entry under
+// `importers`; single-package repos resolve directly off the lockfile's
+// top-level dependency fields. Every other workspace member's own importer
+// entry is walked too (using its own already-resolved specifiers, which is
+// more precise than re-deriving them from extraSeeds), so a member-only
+// dependency the root's own importer doesn't redeclare is still reachable.
+// Peer-hash variants of the same name+version (e.g. "/foo/1.0.0_bar@2.0.0"
+// and "/foo/1.0.0_baz@1.0.0") collapse to a single pkg.ID, and peer
+// dependencies that were never installed (missing from `packages`) are
+// silently dropped.
+func fallbackFromPnpmLockfile(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	lock, err := lockfile.ReadPnpmLockfile(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	rootDeps := mergeDeps(lock.Dependencies, lock.DevDependencies, lock.OptionalDependencies)
+	if importer, ok := lock.Importers["."]; ok {
+		rootDeps = mergeDeps(importer.Dependencies, importer.DevDependencies, importer.OptionalDependencies)
+	}
+	rootDeps = mergeDeps(extraSeeds, rootDeps)
+
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var walk func(deps map[string]string) pkg.Imports
+	walk = func(deps map[string]string) pkg.Imports {
+		var imports pkg.Imports
+		for _, name := range sortedStringKeys(deps) {
+			key := lockfile.PnpmDependencyKey(name, deps[name])
+			entry, ok := lock.Packages[key]
+			if !ok {
+				// Not installed: either a regular optional dependency, or a
+				// peer dependency that went unmet. Either way pnpm already
+				// decided not to fail the install over it (a required peer
+				// going unmet fails `pnpm install` itself), so it's always
+				// safe to drop here.
+				continue
+			}
+
+			_, version := lockfile.ParsePnpmPackageKey(key)
+			id := pkg.ID{Type: pkg.NodeJS, Name: name, Revision: version}
+			imports = append(imports, pkg.Import{Target: name, Resolved: id})
+
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			transitive[id] = pkg.Package{ID: id, Imports: walk(mergeDeps(entry.Dependencies, entry.OptionalDependencies, entry.PeerDependencies))}
+		}
+		return imports
+	}
+
+	direct := walk(rootDeps)
+	for _, location := range sortedImporterLocations(lock.Importers) {
+		if location == "." {
+			continue
+		}
+		importer := lock.Importers[location]
+		walk(mergeDeps(importer.Dependencies, importer.DevDependencies, importer.OptionalDependencies))
+	}
+
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// sortedImporterLocations returns a pnpm-lock.yaml's importer keys (workspace
+// member locations) in a deterministic order.
+func sortedImporterLocations(importers map[string]lockfile.PnpmImporter) []string {
+	locations := make([]string, 0, len(importers))
+	for location := range importers {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+	return locations
+}
+
+// fallbackFromYarnLockfile dispatches to the parser matching the yarn.lock's
+// format: classic (v1), or Berry (v2+, detected by its `__metadata`
+// header).
+func fallbackFromYarnLockfile(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	isBerry, err := lockfile.IsYarnBerryLockfile(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	if isBerry {
+		return fallbackFromYarnLockfileBerry(path, extraSeeds)
+	}
+	return fallbackFromYarnLockfileV1(path, extraSeeds)
+}
+
+// fallbackFromYarnLockfileV1 resolves a module's dependency graph from a
+// classic (v1) yarn.lock.
+func fallbackFromYarnLockfileV1(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	lock, err := lockfile.ReadYarnLockfileV1(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	manifest, err := readPackageJSON(filepath.Join(filepath.Dir(path), "package.json"))
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var resolve func(name, spec string) (pkg.ID, bool)
+	resolve = func(name, spec string) (pkg.ID, bool) {
+		entry, ok := lock[name+"@"+spec]
+		if !ok {
+			return pkg.ID{}, false
+		}
+
+		id := pkg.ID{Type: pkg.NodeJS, Name: name, Revision: entry.Version}
+		if visited[id] {
+			return id, true
+		}
+		visited[id] = true
+
+		var imports pkg.Imports
+		for _, childName := range sortedStringKeys(entry.Dependencies) {
+			if childID, ok := resolve(childName, entry.Dependencies[childName]); ok {
+				imports = append(imports, pkg.Import{Target: childName, Resolved: childID})
+			}
+		}
+		transitive[id] = pkg.Package{ID: id, Imports: imports}
+		return id, true
+	}
+
+	deps := mergeDeps(manifest.Dependencies, manifest.DevDependencies, extraSeeds)
+	var direct pkg.Imports
+	for _, name := range sortedStringKeys(deps) {
+		if id, ok := resolve(name, deps[name]); ok {
+			direct = append(direct, pkg.Import{Target: name, Resolved: id})
+		}
+	}
+
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// fallbackFromYarnLockfileBerry resolves a module's dependency graph from a
+// Yarn Berry (v2+) yarn.lock.
+func fallbackFromYarnLockfileBerry(path string, extraSeeds map[string]string) (pkg.Output, error) {
+	lock, err := lockfile.ReadYarnLockfileBerry(path)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	manifest, err := readPackageJSON(filepath.Join(filepath.Dir(path), "package.json"))
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	transitive := make(pkg.Deps)
+	visited := make(map[pkg.ID]bool)
+
+	var resolve func(name, spec string) (pkg.ID, bool)
+	resolve = func(name, spec string) (pkg.ID, bool) {
+		// package.json ranges have no resolver protocol ("^4.1.2"), while
+		// the dependency specs recorded inside the lockfile itself always
+		// do ("npm:^4.1.2"); try both so a direct dependency resolves the
+		// same way a transitive one does.
+		entry, ok := lock[name+"@"+spec]
+		if !ok {
+			entry, ok = lock[name+"@npm:"+spec]
+		}
+		if !ok {
+			return pkg.ID{}, false
+		}
+		if lockfile.YarnBerryProtocol(entry.Resolution) == "workspace" {
+			// A workspace-protocol resolution points at a sibling monorepo
+			// package, handled by the workspaces analysis strategy; skip it
+			// here to avoid resolving a package against itself.
+			return pkg.ID{}, false
+		}
+
+		id := pkg.ID{Type: pkg.NodeJS, Name: name, Revision: entry.Version}
+		if visited[id] {
+			return id, true
+		}
+		visited[id] = true
+
+		var imports pkg.Imports
+		for _, childName := range sortedStringKeys(entry.Dependencies) {
+			if childID, ok := resolve(childName, entry.Dependencies[childName]); ok {
+				imports = append(imports, pkg.Import{Target: childName, Resolved: childID})
+			}
+		}
+		transitive[id] = pkg.Package{ID: id, Imports: imports}
+		return id, true
+	}
+
+	deps := mergeDeps(manifest.Dependencies, manifest.DevDependencies, extraSeeds)
+	var direct pkg.Imports
+	for _, name := range sortedStringKeys(deps) {
+		if id, ok := resolve(name, deps[name]); ok {
+			direct = append(direct, pkg.Import{Target: name, Resolved: id})
+		}
+	}
+
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// PackageJSON is the subset of package.json fields the analyzer cares
+// about.
+type PackageJSON struct {
+	Name            string            `json:"name"`
+	Version         string            `json:"version"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+	Workspaces      json.RawMessage   `json:"workspaces"`
+}
+
+func readPackageJSON(path string) (PackageJSON, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PackageJSON{}, err
+	}
+
+	var manifest PackageJSON
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return PackageJSON{}, err
+	}
+	return manifest, nil
+}
+
+func mergeDeps(maps ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, m := range maps {
+		for name, spec := range m {
+			merged[name] = spec
+		}
+	}
+	return merged
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func sortedKeys(deps map[string]OutputDep) []string {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -186,7 +186,10 @@ var chaiDirectDep = pkg.Import{
 var npmChaiFixtures = []string{
 	filepath.Join("testdata", "chai", "installed"),
 	filepath.Join("testdata", "chai", "installed-lockfile"),
+	filepath.Join("testdata", "chai", "installed-lockfile-v2"),
+	filepath.Join("testdata", "chai", "installed-lockfile-v3"),
 	filepath.Join("testdata", "chai", "installed-yarn-lockfile"),
+	filepath.Join("testdata", "chai", "installed-pnpm-lockfile"),
 	filepath.Join("testdata", "chai", "installed-shrinkwrap"),
 	filepath.Join("testdata", "chai", "dev-deps"),
 }
@@ -194,6 +197,7 @@ var npmChaiFixtures = []string{
 func TestAnalyzeWithNpmLs(t *testing.T) {
 	t.Parallel()
 	for _, fixturePath := range npmChaiFixtures {
+		fixturePath := fixturePath
 		t.Run(fixturePath, func(t *testing.T) {
 			t.Parallel()
 			testAnalyzeWithNpmLs(t, fixturePath)
@@ -226,6 +230,7 @@ func testAnalyzeWithNpmLs(t *testing.T, buildTarget string) {
 func TestUsingNodeModuleFallback(t *testing.T) {
 	t.Parallel()
 	for _, fixturePath := range npmChaiFixtures {
+		fixturePath := fixturePath
 		t.Run(fixturePath, func(t *testing.T) {
 			t.Parallel()
 			testUsingNodeModuleFallback(t, fixturePath)
@@ -261,6 +266,73 @@ func testUsingNodeModuleFallback(t *testing.T, buildTarget string) {
 	assertImport(t, chaiProject.Imports, "type-detect", "4.0.8")
 }
 
+// TestUsingNpmLockfileV1HoistedFallback checks that a v1 package-lock.json
+// resolves a package's dependencies via its `requires` field and the
+// nesting-scope walk, not just its own (usually empty) `dependencies`
+// subtree — real package-lock.json v1 files hoist the vast majority of
+// packages to the top level and only nest a package locally when a version
+// conflict demands it.
+func TestUsingNpmLockfileV1HoistedFallback(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "chai", "installed-lockfile-hoisted")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	chaiProject := analysisResults.Transitive[chaiDirectDep.Resolved]
+	assert.NotZero(t, chaiProject)
+	assert.Equal(t, 6, len(chaiProject.Imports))
+	assertImport(t, chaiProject.Imports, "assertion-error", "1.1.0")
+	assertImport(t, chaiProject.Imports, "check-error", "1.0.2")
+	assertImport(t, chaiProject.Imports, "get-func-name", "2.0.0")
+	assertImport(t, chaiProject.Imports, "pathval", "1.1.0")
+	assertImport(t, chaiProject.Imports, "deep-eql", "3.0.1")
+	assertImport(t, chaiProject.Imports, "type-detect", "4.0.8")
+
+	deepEql := findPackage(analysisResults.Transitive, "deep-eql", "3.0.1")
+	assert.NotZero(t, deepEql)
+	assertImport(t, deepEql.Imports, "type-detect", "4.0.8")
+}
+
+// TestNpmLockfileV2PeerDependency checks that a peer dependency that was
+// actually installed is walked into Imports like any other dependency,
+// mirroring TestMissingPeerDependency's coverage of the opposite (unmet and
+// dropped) case.
+func TestNpmLockfileV2PeerDependency(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "npm-peer-dep")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	fooProject := findPackage(analysisResults.Transitive, "foo", "1.0.0")
+	assert.NotZero(t, fooProject)
+	assert.Equal(t, 1, len(fooProject.Imports))
+	assertImport(t, fooProject.Imports, "bar", "1.0.0")
+}
+
 func TestUsingYarnLockfileFallback(t *testing.T) {
 	buildTarget := filepath.Join("testdata", "chai", "installed-yarn-lockfile")
 
@@ -288,6 +360,288 @@ func TestUsingYarnLockfileFallback(t *testing.T) {
 	assertImport(t, chaiProject.Imports, "type-detect", "4.0.8")
 }
 
+func TestUsingYarnBerryLockfileFallback(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "chai", "installed-yarn-berry-lockfile")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	chaiProject := analysisResults.Transitive[chaiDirectDep.Resolved]
+	assertImport(t, chaiProject.Imports, "assertion-error", "1.1.0")
+	assertImport(t, chaiProject.Imports, "check-error", "1.0.2")
+	assertImport(t, chaiProject.Imports, "get-func-name", "2.0.0")
+	assertImport(t, chaiProject.Imports, "pathval", "1.1.0")
+	assertImport(t, chaiProject.Imports, "deep-eql", "3.0.1")
+	assertImport(t, chaiProject.Imports, "type-detect", "4.0.8")
+}
+
+// TestYarnBerryLockfileProtocols checks that a `workspace:`-protocol
+// resolution is skipped (it points at a sibling monorepo package, handled
+// by the workspaces analysis strategy instead) while a `patch:`-protocol
+// resolution is walked like any other dependency.
+func TestYarnBerryLockfileProtocols(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "chai", "installed-yarn-berry-lockfile-protocols")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(analysisResults.Direct))
+	assertImport(t, analysisResults.Direct, "patched-pkg", "1.0.0")
+
+	patchedPkg := findPackage(analysisResults.Transitive, "patched-pkg", "1.0.0")
+	assert.NotZero(t, patchedPkg)
+	assertImport(t, patchedPkg.Imports, "leftpad", "1.0.0")
+}
+
+func TestUsingPnpmLockfileFallback(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "chai", "installed-pnpm-lockfile")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	chaiProject := analysisResults.Transitive[chaiDirectDep.Resolved]
+	assertImport(t, chaiProject.Imports, "assertion-error", "1.1.0")
+	assertImport(t, chaiProject.Imports, "check-error", "1.0.2")
+	assertImport(t, chaiProject.Imports, "get-func-name", "2.0.0")
+	assertImport(t, chaiProject.Imports, "pathval", "1.1.0")
+	assertImport(t, chaiProject.Imports, "deep-eql", "3.0.1")
+	assertImport(t, chaiProject.Imports, "type-detect", "4.0.8")
+}
+
+// TestPnpmMissingPeerDependency checks that a peer dependency flagged
+// optional in `peerDependenciesMeta` is silently dropped when pnpm never
+// actually installed it, mirroring TestMissingPeerDependency for npm.
+func TestPnpmMissingPeerDependency(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "pnpm-missing-peer")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	id := pkg.ID{
+		Type:     pkg.NodeJS,
+		Name:     "request",
+		Revision: "",
+		Location: "",
+	}
+	assert.NotContains(t, analysisResults.Transitive, id)
+
+	fooProject := findPackage(analysisResults.Transitive, "foo", "1.0.0")
+	assert.NotZero(t, fooProject)
+	assert.Len(t, fooProject.Imports, 0)
+}
+
+// TestPnpmPeerDependency checks that a peer dependency that was actually
+// installed is walked into Imports like any other dependency, mirroring
+// TestPnpmMissingPeerDependency's coverage of the opposite (unmet and
+// dropped) case.
+func TestPnpmPeerDependency(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "pnpm-peer-dep")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	fooProject := findPackage(analysisResults.Transitive, "foo", "1.0.0")
+	assert.NotZero(t, fooProject)
+	assert.Equal(t, 1, len(fooProject.Imports))
+	assertImport(t, fooProject.Imports, "bar", "1.0.0")
+}
+
+// TestLockfileStrategy checks that, with Options["strategy"] set to
+// "lockfile", Analyze() resolves the dependency graph from package-lock.json
+// even when an installed (and, here, deliberately stale) node_modules/ tree
+// is present, proving that node_modules and `npm ls` are never consulted.
+func TestLockfileStrategy(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "chai", "installed-lockfile-strategy")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{"strategy": "lockfile"},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(analysisResults.Direct))
+	assertImport(t, analysisResults.Direct, "chai", "4.1.2")
+
+	chaiProject := findPackage(analysisResults.Transitive, "chai", "4.1.2")
+	assert.NotZero(t, chaiProject)
+	assert.Equal(t, 6, len(chaiProject.Imports))
+	assertImport(t, chaiProject.Imports, "assertion-error", "1.1.0")
+	assertImport(t, chaiProject.Imports, "check-error", "1.0.2")
+	assertImport(t, chaiProject.Imports, "get-func-name", "2.0.0")
+	assertImport(t, chaiProject.Imports, "pathval", "1.1.0")
+	assertImport(t, chaiProject.Imports, "deep-eql", "3.0.1")
+	assertImport(t, chaiProject.Imports, "type-detect", "4.0.8")
+}
+
+// TestWorkspaceAnalysis checks that, with Options["strategy"] set to
+// "workspaces", each npm workspace under testdata/workspaces/npm-workspaces
+// becomes its own Direct entry; workspace "a"'s dependency on sibling
+// workspace "b" resolves to b's own pkg.ID rather than an external lookup;
+// "a" and "c" each declare their own "lodash" dependency directly (the root
+// package.json never redeclares it - it only depends on its own unrelated
+// "foo" tool, which happens to carry a transitive lodash of its own), so
+// resolving them at all proves the fallback graph is seeded from every
+// workspace member's own deps, not just the root's; and since that seeding
+// surfaces two different lodash versions (one hoisted to root, one nested
+// under "foo"), each workspace resolving to the version that actually
+// satisfies its own declared range (rather than an arbitrary by-name match)
+// proves the semver-range resolution too.
+func TestWorkspaceAnalysis(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "workspaces", "npm-workspaces")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{"strategy": "workspaces"},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, len(analysisResults.Direct))
+	assertImport(t, analysisResults.Direct, "a", "1.0.0")
+	assertImport(t, analysisResults.Direct, "b", "1.0.0")
+	assertImport(t, analysisResults.Direct, "c", "1.0.0")
+
+	packageA := findPackage(analysisResults.Transitive, "a", "1.0.0")
+	assert.NotZero(t, packageA)
+	assert.Equal(t, filepath.Join("packages", "a"), packageA.ID.Location)
+	assert.Equal(t, 2, len(packageA.Imports))
+	assertImport(t, packageA.Imports, "b", "1.0.0")
+	assertImport(t, packageA.Imports, "lodash", "4.17.21")
+
+	packageB := findPackage(analysisResults.Transitive, "b", "1.0.0")
+	assert.NotZero(t, packageB)
+	assert.Equal(t, filepath.Join("packages", "b"), packageB.ID.Location)
+	assert.Equal(t, 0, len(packageB.Imports))
+
+	packageC := findPackage(analysisResults.Transitive, "c", "1.0.0")
+	assert.NotZero(t, packageC)
+	assert.Equal(t, filepath.Join("packages", "c"), packageC.ID.Location)
+	assert.Equal(t, 1, len(packageC.Imports))
+	assertImport(t, packageC.Imports, "lodash", "3.10.1")
+
+	lodash4 := findPackage(analysisResults.Transitive, "lodash", "4.17.21")
+	assert.NotZero(t, lodash4)
+
+	lodash3 := findPackage(analysisResults.Transitive, "lodash", "3.10.1")
+	assert.NotZero(t, lodash3)
+}
+
+// TestPnpmWorkspaceAnalysis checks that, for a pnpm monorepo, workspace
+// analysis walks every member's own `importers` entry (not just the root's),
+// and that peer-hash variants of the same name+version recorded under
+// different importers (here "foo"'s "_bar@2.0.0" and "_baz@1.0.0" variants,
+// each only ever declared by one workspace) collapse to the single pkg.ID
+// both workspaces actually resolve their "foo" dependency to.
+func TestPnpmWorkspaceAnalysis(t *testing.T) {
+	buildTarget := filepath.Join("testdata", "workspaces", "pnpm-workspaces")
+
+	nodeModule := module.Module{
+		Name:        "test",
+		Type:        pkg.NodeJS,
+		BuildTarget: buildTarget,
+		Options:     map[string]interface{}{"strategy": "workspaces"},
+	}
+
+	analyzer, err := nodejs.New(nodeModule, false)
+	assert.NoError(t, err)
+
+	analyzer.NPM = MockNPMFailure{}
+
+	analysisResults, err := analyzer.Analyze()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(analysisResults.Direct))
+	assertImport(t, analysisResults.Direct, "a", "1.0.0")
+	assertImport(t, analysisResults.Direct, "b", "1.0.0")
+
+	packageA := findPackage(analysisResults.Transitive, "a", "1.0.0")
+	assert.NotZero(t, packageA)
+	assert.Equal(t, 1, len(packageA.Imports))
+	assertImport(t, packageA.Imports, "foo", "1.0.0")
+
+	packageB := findPackage(analysisResults.Transitive, "b", "1.0.0")
+	assert.NotZero(t, packageB)
+	assert.Equal(t, 1, len(packageB.Imports))
+	assertImport(t, packageB.Imports, "foo", "1.0.0")
+
+	assert.Equal(t, packageA.Imports[0].Resolved, packageB.Imports[0].Resolved)
+}
+
 func findPackage(packages map[pkg.ID]pkg.Package, name, revision string) pkg.Package {
 	for id := range packages {
 		if id.Name == name && id.Revision == revision {
@@ -0,0 +1,51 @@
+package nodejs
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+)
+
+// NPM wraps the subset of `npm` functionality the analyzer needs. It exists
+// as an interface so that tests can substitute a mock instead of shelling
+// out to a real `npm` binary.
+type NPM interface {
+	List(dir string) (Output, error)
+}
+
+// Output is the JSON emitted by `npm ls --json`.
+type Output struct {
+	Name         string               `json:"name"`
+	Version      string               `json:"version"`
+	Dependencies map[string]OutputDep `json:"dependencies"`
+}
+
+// OutputDep is a single entry in the `dependencies` tree of `npm ls --json`.
+type OutputDep struct {
+	Version      string               `json:"version"`
+	Resolved     string               `json:"resolved"`
+	PeerMissing  bool                 `json:"peerMissing"`
+	Dependencies map[string]OutputDep `json:"dependencies"`
+}
+
+// SystemNPM shells out to the `npm` binary on $PATH.
+type SystemNPM struct{}
+
+// List runs `npm ls --json` in dir and parses its output.
+func (SystemNPM) List(dir string) (Output, error) {
+	cmd := exec.Command("npm", "ls", "--json", "--production=false")
+	cmd.Dir = dir
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	// `npm ls` exits non-zero whenever the tree has unmet peer dependencies,
+	// even when it has still printed a usable tree, so we ignore the run
+	// error and only fail if we can't parse the output at all.
+	_ = cmd.Run()
+
+	var out Output
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return Output{}, err
+	}
+	return out, nil
+}
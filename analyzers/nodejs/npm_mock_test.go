@@ -0,0 +1,36 @@
+package nodejs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/fossas/fossa-cli/analyzers/nodejs"
+)
+
+// MockNPM returns the `npm ls --json` output recorded in JSONFilename,
+// instead of shelling out to a real `npm` binary.
+type MockNPM struct {
+	JSONFilename string
+}
+
+func (m MockNPM) List(dir string) (nodejs.Output, error) {
+	contents, err := ioutil.ReadFile(m.JSONFilename)
+	if err != nil {
+		return nodejs.Output{}, err
+	}
+
+	var output nodejs.Output
+	if err := json.Unmarshal(contents, &output); err != nil {
+		return nodejs.Output{}, err
+	}
+	return output, nil
+}
+
+// MockNPMFailure simulates an environment where `npm` cannot be run at all,
+// forcing the analyzer down its fallback path.
+type MockNPMFailure struct{}
+
+func (MockNPMFailure) List(dir string) (nodejs.Output, error) {
+	return nodejs.Output{}, errors.New("npm: command not found")
+}
@@ -0,0 +1,239 @@
+package nodejs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v2"
+
+	"github.com/fossas/fossa-cli/pkg"
+)
+
+// analyzeWorkspaces expands the module into its workspace sub-projects. Each
+// workspace becomes its own entry in Direct with a synthetic pkg.ID, and its
+// resolved dependency subgraph is merged into Transitive alongside the
+// root's own externally-resolved dependencies (e.g. hoisted packages found
+// via the root node_modules/ tree or lockfile). Dependencies that name
+// another workspace are linked directly to that workspace's pkg.ID instead
+// of being resolved externally.
+//
+// A real monorepo's root package.json usually declares just its own shared
+// tooling deps, not every package a member needs, so the root's fallback
+// resolution is seeded with the union of every member's own
+// dependencies/devDependencies too (minus names that are themselves
+// workspace members) - otherwise a member-only dependency that never shows
+// up at the root is silently missing from the graph entirely.
+func (a *Analyzer) analyzeWorkspaces() (pkg.Output, error) {
+	root := a.dir()
+
+	patterns, err := workspacePatterns(root)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+	workspaceDirs, err := expandWorkspacePatterns(root, patterns)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	type workspace struct {
+		id   pkg.ID
+		deps map[string]string
+	}
+
+	byWorkspaceName := make(map[string]pkg.ID)
+	var workspaces []workspace
+	for _, dir := range workspaceDirs {
+		manifest, err := readPackageJSON(filepath.Join(dir, "package.json"))
+		if err != nil {
+			return pkg.Output{}, err
+		}
+
+		location, err := filepath.Rel(root, dir)
+		if err != nil {
+			location = dir
+		}
+
+		id := pkg.ID{Type: pkg.NodeJS, Name: manifest.Name, Revision: manifest.Version, Location: location}
+		byWorkspaceName[manifest.Name] = id
+		workspaces = append(workspaces, workspace{
+			id:   id,
+			deps: mergeDeps(manifest.Dependencies, manifest.DevDependencies),
+		})
+	}
+
+	seeds := make(map[string]string)
+	for _, ws := range workspaces {
+		for name, spec := range ws.deps {
+			if _, ok := byWorkspaceName[name]; ok {
+				continue
+			}
+			seeds[name] = spec
+		}
+	}
+
+	rootOutput, err := a.fallback(seeds)
+	if err != nil {
+		return pkg.Output{}, err
+	}
+
+	// A name may map to more than one resolved version in a real monorepo
+	// (one workspace needs lodash@^3, another pulls lodash@^4 transitively),
+	// so keep every candidate and pick the one each workspace's own declared
+	// range is satisfied by, rather than an arbitrary by-name entry.
+	byPackageName := make(map[string][]pkg.ID)
+	for id := range rootOutput.Transitive {
+		byPackageName[id.Name] = append(byPackageName[id.Name], id)
+	}
+	for name, candidates := range byPackageName {
+		sort.Slice(candidates, func(i, j int) bool { return versionLess(candidates[i].Revision, candidates[j].Revision) })
+		byPackageName[name] = candidates
+	}
+
+	transitive := make(pkg.Deps)
+	for id, p := range rootOutput.Transitive {
+		transitive[id] = p
+	}
+
+	var direct pkg.Imports
+	for _, ws := range workspaces {
+		var imports pkg.Imports
+		for _, name := range sortedStringKeys(ws.deps) {
+			if wsID, ok := byWorkspaceName[name]; ok {
+				imports = append(imports, pkg.Import{Target: name, Resolved: wsID})
+				continue
+			}
+			if id, ok := resolveWorkspaceDep(byPackageName[name], ws.deps[name]); ok {
+				imports = append(imports, pkg.Import{Target: name, Resolved: id})
+			}
+		}
+		transitive[ws.id] = pkg.Package{ID: ws.id, Imports: imports}
+		direct = append(direct, pkg.Import{Target: ws.id.Name, Resolved: ws.id})
+	}
+
+	return pkg.Output{Direct: direct, Transitive: transitive}, nil
+}
+
+// resolveWorkspaceDep picks the candidate (sorted ascending by version) that
+// satisfies spec, preferring the highest match. If spec isn't a parseable
+// semver range (a git/file reference, "*", "latest", ...) or no candidate
+// satisfies it, it falls back to the single remaining or highest-versioned
+// candidate so resolution is always deterministic, never dependent on map
+// iteration order.
+func resolveWorkspaceDep(candidates []pkg.ID, spec string) (pkg.ID, bool) {
+	if len(candidates) == 0 {
+		return pkg.ID{}, false
+	}
+
+	if constraint, err := semver.NewConstraint(spec); err == nil {
+		for i := len(candidates) - 1; i >= 0; i-- {
+			version, err := semver.NewVersion(candidates[i].Revision)
+			if err == nil && constraint.Check(version) {
+				return candidates[i], true
+			}
+		}
+	}
+
+	return candidates[len(candidates)-1], true
+}
+
+// versionLess orders two package revisions for resolveWorkspaceDep's
+// highest-match-first scan, falling back to a plain string comparison for
+// revisions that aren't valid semver.
+func versionLess(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA == nil && errB == nil {
+		return va.LessThan(vb)
+	}
+	return a < b
+}
+
+// workspacePatterns returns the workspace glob patterns declared for dir, in
+// whichever of the supported forms it finds first: npm/yarn's `workspaces`
+// field in package.json (as a bare array or a `{ "packages": [...] }`
+// object), or pnpm's pnpm-workspace.yaml.
+func workspacePatterns(dir string) ([]string, error) {
+	manifest, err := readPackageJSON(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	if patterns := parseWorkspacesField(manifest.Workspaces); patterns != nil {
+		return patterns, nil
+	}
+
+	if path := filepath.Join(dir, "pnpm-workspace.yaml"); exists(path) {
+		return readPnpmWorkspaceFile(path)
+	}
+
+	return nil, nil
+}
+
+// parseWorkspacesField parses package.json's `workspaces` field, which may
+// be either a bare array of glob patterns or an object with a `packages`
+// array (the form used when yarn's nohoist option is also configured).
+func parseWorkspacesField(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(raw, &patterns); err == nil {
+		return patterns
+	}
+
+	var withPackages struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(raw, &withPackages); err == nil {
+		return withPackages.Packages
+	}
+
+	return nil
+}
+
+// pnpmWorkspaceFile is the subset of pnpm-workspace.yaml fields needed to
+// discover workspace member directories.
+type pnpmWorkspaceFile struct {
+	Packages []string `yaml:"packages"`
+}
+
+func readPnpmWorkspaceFile(path string) ([]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file pnpmWorkspaceFile
+	if err := yaml.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+	return file.Packages, nil
+}
+
+// expandWorkspacePatterns resolves workspace glob patterns (relative to
+// dir) to the directories they match, skipping negated patterns (prefixed
+// with "!") and any match that doesn't contain its own package.json.
+func expandWorkspacePatterns(dir string, patterns []string) ([]string, error) {
+	var dirs []string
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if exists(filepath.Join(match, "package.json")) {
+				dirs = append(dirs, match)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
@@ -0,0 +1,15 @@
+// Package module defines the representation of a single analyzable module
+// within a project, as discovered during `fossa init` and passed to
+// analyzers during `fossa analyze`.
+package module
+
+import "github.com/fossas/fossa-cli/pkg"
+
+// Module is a single analyzable unit, such as a `package.json` or a Gemfile.
+type Module struct {
+	Name        string
+	Type        pkg.Type
+	BuildTarget string
+	Dir         string
+	Options     map[string]interface{}
+}
@@ -0,0 +1,66 @@
+// Package pkg provides common types for describing packages and their
+// dependency graphs across all of fossa-cli's analyzers.
+package pkg
+
+import "fmt"
+
+// Type represents the ecosystem a package belongs to.
+type Type int
+
+const (
+	NodeJS Type = iota
+	Ruby
+	Commonjs
+)
+
+func (t Type) String() string {
+	switch t {
+	case NodeJS:
+		return "nodejs"
+	case Ruby:
+		return "ruby"
+	case Commonjs:
+		return "commonjs"
+	default:
+		return "unknown"
+	}
+}
+
+// ID uniquely identifies a resolved package within its ecosystem.
+type ID struct {
+	Type     Type
+	Name     string
+	Revision string
+	Location string
+}
+
+func (id ID) String() string {
+	return fmt.Sprintf("%s %s@%s", id.Type, id.Name, id.Revision)
+}
+
+// Import is an edge in a dependency graph: the string used to reference a
+// package (as written in a manifest or lockfile) resolved to a concrete ID.
+type Import struct {
+	Target   string
+	Resolved ID
+}
+
+// Imports is a set of Import edges.
+type Imports []Import
+
+// Package is a resolved dependency together with the edges to its own
+// dependencies.
+type Package struct {
+	ID
+	Imports Imports
+}
+
+// Deps is a dependency graph's transitive closure, keyed by ID.
+type Deps map[ID]Package
+
+// Output is the result of analyzing a module: its direct dependencies, and
+// the full transitive closure reachable from them.
+type Output struct {
+	Direct     Imports
+	Transitive Deps
+}